@@ -1,9 +1,10 @@
 package main
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
@@ -11,8 +12,13 @@ import (
 
 	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/mux"
+
+	"github.com/shobhitexe/GoBank/pkg/audit"
+	"github.com/shobhitexe/GoBank/pkg/auth"
 )
 
+const oauthStateCookie = "oauth_state"
+
 func WriteJSON(w http.ResponseWriter, status int, v any) error {
 
 	w.Header().Set("Content-Type", "application/json")
@@ -36,32 +42,60 @@ func makeHTTPHandlerFunc(f apiFunc) http.HandlerFunc {
 }
 
 type APIServer struct {
-	listenAddr string
-	store      Storage
+	listenAddr     string
+	store          Storage
+	oauthProviders map[string]auth.OAuthProvider
 }
 
 func NewAPIServer(listenAddr string, store Storage) *APIServer {
 	return &APIServer{
 		listenAddr: listenAddr,
 		store:      store,
+		oauthProviders: map[string]auth.OAuthProvider{
+			"google": auth.NewGoogleProvider(os.Getenv("GOOGLE_CLIENT_ID"), os.Getenv("GOOGLE_CLIENT_SECRET"), os.Getenv("GOOGLE_REDIRECT_URL")),
+			"github": auth.NewGitHubProvider(os.Getenv("GITHUB_CLIENT_ID"), os.Getenv("GITHUB_CLIENT_SECRET"), os.Getenv("GITHUB_REDIRECT_URL")),
+		},
 	}
 }
 
-func (s *APIServer) Run() {
+func (s *APIServer) routes() *mux.Router {
 
 	router := mux.NewRouter()
 
 	router.HandleFunc("/login", makeHTTPHandlerFunc(s.handleLogin))
 
-	router.HandleFunc("/account", makeHTTPHandlerFunc(s.handleAccount))
+	router.HandleFunc("/register", makeHTTPHandlerFunc(s.handleRegister))
+
+	router.HandleFunc("/account", requireRole(RoleAdmin)(makeHTTPHandlerFunc(s.handleAccount), s.store))
 
-	router.HandleFunc("/account/{id}", withJWTAuth(makeHTTPHandlerFunc(s.handleGetAccountByID), s.store))
+	router.HandleFunc("/account/{id}", requireOwnerOrRole(RoleAdmin)(makeHTTPHandlerFunc(s.handleGetAccountByID), s.store)).Methods("GET")
+
+	router.HandleFunc("/account/{id}", requireRole(RoleAdmin)(makeHTTPHandlerFunc(s.handleDeleteAccount), s.store)).Methods("DELETE")
 
 	router.HandleFunc("/transfer", makeHTTPHandlerFunc(s.handleTransferAccount))
 
-	log.Println("JSON API server running on port: ", s.listenAddr)
+	router.HandleFunc("/auth/refresh", makeHTTPHandlerFunc(s.handleRefresh))
+
+	router.HandleFunc("/auth/logout", makeHTTPHandlerFunc(s.handleLogout))
+
+	router.HandleFunc("/auth/logout-all", makeHTTPHandlerFunc(s.handleLogoutAll))
 
-	http.ListenAndServe(s.listenAddr, router)
+	router.HandleFunc("/auth/{provider}/login", makeHTTPHandlerFunc(s.handleOAuthLogin))
+
+	router.HandleFunc("/auth/{provider}/callback", makeHTTPHandlerFunc(s.handleOAuthCallback))
+
+	router.HandleFunc("/admin/audit", requireRole(RoleAdmin)(makeHTTPHandlerFunc(s.handleListAuditEvents), s.store)).Methods("GET")
+
+	return router
+}
+
+func (s *APIServer) Run() {
+
+	slog.Info("starting JSON API server", "listenAddr", s.listenAddr)
+
+	if err := http.ListenAndServe(s.listenAddr, withRequestLogging(s.routes())); err != nil {
+		slog.Error("server stopped", "error", err)
+	}
 
 }
 
@@ -80,22 +114,29 @@ func (s *APIServer) handleLogin(w http.ResponseWriter, r *http.Request) error {
 	acc, err := s.store.GetAccountByNumber(int(req.Number))
 
 	if err != nil {
+		recordAudit(r.Context(), s.store, nil, audit.ActionLoginFailure, nil, r, map[string]int64{"number": req.Number}, audit.ResultFailure)
+
 		return err
 	}
 
-	if acc.ValidatePassword(req.Password) {
+	if !acc.ValidatePassword(req.Password) {
+		recordAudit(r.Context(), s.store, &acc.ID, audit.ActionLoginFailure, nil, r, map[string]int64{"number": acc.Number}, audit.ResultFailure)
+
 		return fmt.Errorf("not authenticated")
 	}
 
-	token, err := createJWT(acc)
+	tokens, err := issueSession(r.Context(), s.store, acc, r)
 
 	if err != nil {
 		return err
 	}
 
+	recordAudit(r.Context(), s.store, &acc.ID, audit.ActionLoginSuccess, nil, r, map[string]int64{"number": acc.Number}, audit.ResultSuccess)
+
 	resp := LoginResponse{
-		Token:  token,
-		Number: acc.Number,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		Number:       acc.Number,
 	}
 
 	return WriteJSON(w, http.StatusOK, resp)
@@ -134,33 +175,65 @@ func (s *APIServer) handleGetAccount(w http.ResponseWriter, _ *http.Request) err
 
 func (s *APIServer) handleGetAccountByID(w http.ResponseWriter, r *http.Request) error {
 
-	if r.Method == "GET" {
+	id, err := getID(r)
 
-		id, err := getID(r)
+	if err != nil {
+		return err
+	}
 
-		if err != nil {
-			return err
-		}
+	account, err := s.store.GetAccountByID(id)
+
+	if err != nil {
+		return err
+	}
 
-		account, err := s.store.GetAccountByID(id)
+	return WriteJSON(w, http.StatusOK, account)
 
-		if err != nil {
-			return err
-		}
+}
+
+func (s *APIServer) handleCreateAccount(w http.ResponseWriter, r *http.Request) error {
+
+	createAccountReq := new(CreateAccountRequest)
 
-		return WriteJSON(w, http.StatusOK, account)
+	if err := json.NewDecoder(r.Body).Decode(createAccountReq); err != nil {
+		return err
 	}
 
-	if r.Method == "DELETE" {
-		return s.handleDeleteAccount(w, r)
+	account, err := NewAccount(createAccountReq.FirstName, createAccountReq.LastName, createAccountReq.Password)
+
+	if err != nil {
+		return err
+	}
+
+	if createAccountReq.Role == RoleAdmin {
+		account.Role = RoleAdmin
 	}
 
-	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	return fmt.Errorf("method %s not allowed", r.Method)
+	if err := s.store.CreateAccount(account); err != nil {
+		return err
+	}
+
+	var actorID *int64
+
+	if actor := accountFromContext(r.Context()); actor != nil {
+		actorID = &actor.ID
+	}
+
+	recordAudit(r.Context(), s.store, actorID, audit.ActionCreateAccount, &account.ID, r, map[string]string{
+		"firstName": account.FirstName,
+		"lastName":  account.LastName,
+		"role":      account.Role,
+	}, audit.ResultSuccess)
+
+	return WriteJSON(w, http.StatusOK, account)
 
 }
 
-func (s *APIServer) handleCreateAccount(w http.ResponseWriter, r *http.Request) error {
+func (s *APIServer) handleRegister(w http.ResponseWriter, r *http.Request) error {
+
+	if r.Method != "POST" {
+		return fmt.Errorf("method %s not allowed", r.Method)
+	}
 
 	createAccountReq := new(CreateAccountRequest)
 
@@ -178,6 +251,12 @@ func (s *APIServer) handleCreateAccount(w http.ResponseWriter, r *http.Request)
 		return err
 	}
 
+	recordAudit(r.Context(), s.store, &account.ID, audit.ActionCreateAccount, nil, r, map[string]string{
+		"firstName": account.FirstName,
+		"lastName":  account.LastName,
+		"role":      account.Role,
+	}, audit.ResultSuccess)
+
 	return WriteJSON(w, http.StatusOK, account)
 
 }
@@ -194,12 +273,26 @@ func (s *APIServer) handleDeleteAccount(w http.ResponseWriter, r *http.Request)
 		return err
 	}
 
+	var actorID *int64
+
+	if actor := accountFromContext(r.Context()); actor != nil {
+		actorID = &actor.ID
+	}
+
+	targetID := int64(id)
+
+	recordAudit(r.Context(), s.store, actorID, audit.ActionDeleteAccount, &targetID, r, map[string]int{"id": id}, audit.ResultSuccess)
+
 	return WriteJSON(w, http.StatusOK, map[string]int{"deleted": id})
 
 }
 
 func (s *APIServer) handleTransferAccount(w http.ResponseWriter, r *http.Request) error {
 
+	if r.Method != "POST" {
+		return fmt.Errorf("method %s not allowed", r.Method)
+	}
+
 	transferRequest := new(TransferRequest)
 
 	if err := json.NewDecoder(r.Body).Decode(transferRequest); err != nil {
@@ -208,85 +301,298 @@ func (s *APIServer) handleTransferAccount(w http.ResponseWriter, r *http.Request
 
 	defer r.Body.Close()
 
-	return WriteJSON(w, http.StatusOK, transferRequest)
+	if transferRequest.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+
+	account, ok := authenticate(w, r, s.store)
+
+	if !ok {
+		return nil
+	}
+
+	r = r.WithContext(contextWithAccount(r.Context(), account))
+
+	fromAccount, err := s.store.GetAccountByID(int(transferRequest.FromAccount))
+
+	if err != nil {
+		return err
+	}
+
+	if fromAccount.Number != account.Number {
+		PermissionDenied(w)
+
+		return nil
+	}
+
+	result, err := s.store.TransferTx(r.Context(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   transferRequest.ToAccount,
+		Amount:        transferRequest.Amount,
+	})
+
+	targetID := transferRequest.ToAccount
+
+	if err != nil {
+		recordAudit(r.Context(), s.store, &account.ID, audit.ActionTransfer, &targetID, r, transferRequest, audit.ResultFailure)
+
+		return err
+	}
+
+	recordAudit(r.Context(), s.store, &account.ID, audit.ActionTransfer, &targetID, r, transferRequest, audit.ResultSuccess)
+
+	return WriteJSON(w, http.StatusOK, result)
 
 }
 
-func createJWT(account *Account) (string, error) {
+func (s *APIServer) handleOAuthLogin(w http.ResponseWriter, r *http.Request) error {
 
-	claims := &jwt.MapClaims{
-		"ExpiresAt":     jwt.NewNumericDate(time.Unix(1516239022, 0)),
-		"AccountNumber": account.Number,
+	provider, ok := s.oauthProviders[mux.Vars(r)["provider"]]
+
+	if !ok {
+		return fmt.Errorf("unknown oauth provider %s", mux.Vars(r)["provider"])
 	}
 
-	secret := os.Getenv("JWT_SECRET")
+	nonce, signedState, err := auth.NewState(os.Getenv("JWT_SECRET"))
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    signedState,
+		Path:     "/",
+		MaxAge:   300,
+		HttpOnly: true,
+	})
 
-	return token.SignedString([]byte(secret))
+	http.Redirect(w, r, provider.AuthURL(nonce), http.StatusFound)
 
+	return nil
 }
 
-func PermissionDenied(w http.ResponseWriter) {
-	WriteJSON(w, http.StatusForbidden, APIError{
-		Error: "Forbidden",
+func (s *APIServer) handleOAuthCallback(w http.ResponseWriter, r *http.Request) error {
+
+	provider, ok := s.oauthProviders[mux.Vars(r)["provider"]]
+
+	if !ok {
+		return fmt.Errorf("unknown oauth provider %s", mux.Vars(r)["provider"])
+	}
+
+	stateCookie, err := r.Cookie(oauthStateCookie)
+
+	if err != nil {
+		return fmt.Errorf("missing oauth state cookie")
+	}
+
+	if !auth.ValidState(os.Getenv("JWT_SECRET"), stateCookie.Value, r.URL.Query().Get("state")) {
+		return fmt.Errorf("invalid oauth state")
+	}
+
+	token, err := provider.Exchange(r.Context(), r.URL.Query().Get("code"))
+
+	if err != nil {
+		return err
+	}
+
+	profile, err := provider.FetchProfile(r.Context(), token)
+
+	if err != nil {
+		return err
+	}
+
+	account, err := s.store.UpsertOAuthAccount(r.Context(), mux.Vars(r)["provider"], profile.Subject, profile)
+
+	if err != nil {
+		return err
+	}
+
+	tokens, err := issueSession(r.Context(), s.store, account, r)
+
+	if err != nil {
+		return err
+	}
+
+	return WriteJSON(w, http.StatusOK, LoginResponse{
+		Number:       account.Number,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// handleRefresh validates the caller's refresh token against the session it
+// names, rotates it (the old session is revoked, a new one takes its place),
+// and returns a fresh access/refresh token pair.
+func (s *APIServer) handleRefresh(w http.ResponseWriter, r *http.Request) error {
+
+	if r.Method != "POST" {
+		return fmt.Errorf("method %s not allowed", r.Method)
+	}
+
+	var req RefreshRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	sessionID, secret, ok := splitRefreshToken(req.RefreshToken)
+
+	if !ok {
+		PermissionDenied(w)
+
+		return nil
+	}
+
+	session, err := s.store.GetSessionByID(r.Context(), sessionID)
+
+	if err != nil || session.RevokedAt != nil || session.ExpiresAt.Before(time.Now().UTC()) {
+		PermissionDenied(w)
+
+		return nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(session.RefreshTokenHash), []byte(hashRefreshSecret(secret))) != 1 {
+		PermissionDenied(w)
+
+		return nil
+	}
+
+	account, err := s.store.GetAccountByID(int(session.AccountID))
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.RevokeSession(r.Context(), session.ID); err != nil {
+		return err
+	}
+
+	tokens, err := issueSession(r.Context(), s.store, account, r)
+
+	if err != nil {
+		return err
+	}
+
+	return WriteJSON(w, http.StatusOK, LoginResponse{
+		Number:       account.Number,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	})
+}
+
+// handleLogout revokes the session the caller's access token was issued
+// under, so that token (and its refresh token) can no longer be used.
+func (s *APIServer) handleLogout(w http.ResponseWriter, r *http.Request) error {
+
+	if r.Method != "POST" {
+		return fmt.Errorf("method %s not allowed", r.Method)
+	}
+
+	token, err := validateJWT(r.Header.Get("Authorization"))
+
+	if err != nil || !token.Valid {
+		PermissionDenied(w)
 
+		return nil
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+
+	if err := s.store.RevokeSession(r.Context(), fmt.Sprint(claims["jti"])); err != nil {
+		return err
+	}
+
+	return WriteJSON(w, http.StatusOK, map[string]bool{"loggedOut": true})
 }
 
-func withJWTAuth(handlerFunc http.HandlerFunc, s Storage) http.HandlerFunc {
+// handleLogoutAll revokes every session belonging to the caller's account,
+// signing them out everywhere at once.
+func (s *APIServer) handleLogoutAll(w http.ResponseWriter, r *http.Request) error {
 
-	return func(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		return fmt.Errorf("method %s not allowed", r.Method)
+	}
+
+	account, ok := authenticate(w, r, s.store)
+
+	if !ok {
+		return nil
+	}
 
-		tokenString := r.Header.Get("Authorization")
+	r = r.WithContext(contextWithAccount(r.Context(), account))
 
-		token, err := validateJWT(tokenString)
+	if err := s.store.RevokeAllSessions(r.Context(), account.ID); err != nil {
+		return err
+	}
 
-		if err != nil && !token.Valid {
-			PermissionDenied(w)
+	return WriteJSON(w, http.StatusOK, map[string]bool{"loggedOut": true})
+}
 
-			return
+// handleListAuditEvents pages through the audit trail, most recent first,
+// optionally narrowed by the account_id/action/since query parameters.
+func (s *APIServer) handleListAuditEvents(w http.ResponseWriter, r *http.Request) error {
 
-		}
+	if r.Method != "GET" {
+		return fmt.Errorf("method %s not allowed", r.Method)
+	}
 
-		userID, err := getID(r)
+	query := r.URL.Query()
 
-		if err != nil {
-			PermissionDenied(w)
+	filter := audit.Filter{Action: query.Get("action")}
 
-			return
+	if v := query.Get("account_id"); v != "" {
+		accountID, err := strconv.ParseInt(v, 10, 64)
+
+		if err != nil {
+			return fmt.Errorf("invalid account_id %q", v)
 		}
 
-		account, err := s.GetAccountByID(userID)
+		filter.AccountID = accountID
+	}
+
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
 
 		if err != nil {
-			PermissionDenied(w)
+			return fmt.Errorf("invalid since %q, want RFC3339", v)
+		}
+
+		filter.Since = since
+	}
 
-			return
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+
+		if err != nil {
+			return fmt.Errorf("invalid limit %q", v)
 		}
 
-		claims := token.Claims.(jwt.MapClaims)
+		filter.Limit = limit
+	}
 
-		if account.Number != int64(claims["AccountNumber"].(float64)) {
-			PermissionDenied(w)
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
 
-			return
+		if err != nil {
+			return fmt.Errorf("invalid offset %q", v)
 		}
 
-		handlerFunc(w, r)
+		filter.Offset = offset
 	}
 
-}
+	events, err := s.store.ListAuditEvents(r.Context(), filter)
 
-func validateJWT(tokenString string) (*jwt.Token, error) {
+	if err != nil {
+		return err
+	}
 
-	secret := os.Getenv("JWT_SECRET")
+	return WriteJSON(w, http.StatusOK, events)
+}
 
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
+func PermissionDenied(w http.ResponseWriter) {
+	WriteJSON(w, http.StatusForbidden, APIError{
+		Error: "Forbidden",
 	})
 
 }
@@ -0,0 +1,564 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shobhitexe/GoBank/pkg/audit"
+)
+
+func init() {
+	os.Setenv("JWT_SECRET", "test-secret")
+}
+
+// sessionIDFor deterministically derives the session id an access token for
+// acc should carry, so a test can mint the token and stub GetSessionByID for
+// it independently of one another.
+func sessionIDFor(acc *Account) string {
+	return fmt.Sprintf("session-%d", acc.ID)
+}
+
+// testJWT mints a valid access token for acc so tests can exercise
+// authenticate-protected routes.
+func testJWT(t *testing.T, acc *Account) string {
+	t.Helper()
+
+	token, err := createAccessToken(acc, sessionIDFor(acc))
+
+	assert.Nil(t, err)
+
+	return token
+}
+
+// testActiveSession returns the session authenticate should find when asked
+// about the session backing testJWT(t, acc).
+func testActiveSession(acc *Account) *Session {
+	return &Session{
+		ID:        sessionIDFor(acc),
+		AccountID: acc.ID,
+		ExpiresAt: time.Now().UTC().Add(time.Hour),
+	}
+}
+
+func testAccount(id int, number int64, role string) *Account {
+	return &Account{
+		ID:        int64(id),
+		FirstName: "A",
+		LastName:  "B",
+		Number:    number,
+		Balance:   100,
+		Role:      role,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// testAccountWithPassword returns a testAccount whose EncryptedPassword is a
+// real bcrypt hash of password, so tests can exercise ValidatePassword with
+// genuinely correct and incorrect passwords instead of relying on bcrypt
+// erroring out on an empty hash.
+func testAccountWithPassword(t *testing.T, id int, number int64, role, password string) *Account {
+	t.Helper()
+
+	acc, err := NewAccount("A", "B", password)
+
+	assert.Nil(t, err)
+
+	acc.ID = int64(id)
+	acc.Number = number
+	acc.Role = role
+
+	return acc
+}
+
+func doRequest(router http.Handler, method, target string, body any, bearer string) *httptest.ResponseRecorder {
+
+	var buf bytes.Buffer
+
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+
+	req := httptest.NewRequest(method, target, &buf)
+
+	if bearer != "" {
+		req.Header.Set("Authorization", bearer)
+	}
+
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestHandleLogin(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		req        LoginRequest
+		setupMock  func(store *MockStorage)
+		wantStatus int
+	}{
+		{
+			name: "account not found",
+			req:  LoginRequest{Number: 1, Password: "wrong"},
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetAccountByNumber(1).Return(nil, fmt.Errorf("account 1 not found"))
+				store.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "correct password succeeds",
+			req:  LoginRequest{Number: 1, Password: "correct-horse-battery-staple"},
+			setupMock: func(store *MockStorage) {
+				acc := testAccountWithPassword(t, 1, 1, RoleUser, "correct-horse-battery-staple")
+				store.EXPECT().GetAccountByNumber(1).Return(acc, nil)
+				store.EXPECT().CreateSession(gomock.Any(), acc.ID, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(testActiveSession(acc), nil)
+				store.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "wrong password is rejected",
+			req:  LoginRequest{Number: 1, Password: "wrong"},
+			setupMock: func(store *MockStorage) {
+				acc := testAccountWithPassword(t, 1, 1, RoleUser, "correct-horse-battery-staple")
+				store.EXPECT().GetAccountByNumber(1).Return(acc, nil)
+				store.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctrl := gomock.NewController(t)
+			store := NewMockStorage(ctrl)
+			tt.setupMock(store)
+
+			server := NewAPIServer(":0", store)
+
+			rec := doRequest(server.routes(), http.MethodPost, "/login", tt.req, "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandleGetAccountByID(t *testing.T) {
+
+	acc := testAccount(1, 42, RoleUser)
+	otherAcc := testAccount(2, 99, RoleUser)
+	admin := testAccount(3, 7, RoleAdmin)
+
+	tests := []struct {
+		name       string
+		bearer     string
+		setupMock  func(store *MockStorage)
+		wantStatus int
+	}{
+		{
+			name:   "owner can fetch their account",
+			bearer: testJWT(t, acc),
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(acc)).Return(testActiveSession(acc), nil)
+				// authenticate resolves the caller, requireOwnerOrRole checks ownership, the handler loads the target again to respond.
+				store.EXPECT().GetAccountByID(1).Return(acc, nil).Times(3)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "mismatched account is forbidden",
+			bearer: testJWT(t, otherAcc),
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(otherAcc)).Return(testActiveSession(otherAcc), nil)
+				store.EXPECT().GetAccountByID(2).Return(otherAcc, nil)
+				store.EXPECT().GetAccountByID(1).Return(acc, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "admin can fetch any account",
+			bearer: testJWT(t, admin),
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(admin)).Return(testActiveSession(admin), nil)
+				store.EXPECT().GetAccountByID(3).Return(admin, nil)
+				store.EXPECT().GetAccountByID(1).Return(acc, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctrl := gomock.NewController(t)
+			store := NewMockStorage(ctrl)
+			tt.setupMock(store)
+
+			server := NewAPIServer(":0", store)
+
+			rec := doRequest(server.routes(), http.MethodGet, "/account/1", nil, tt.bearer)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandleCreateAccount(t *testing.T) {
+
+	admin := testAccount(1, 7, RoleAdmin)
+	user := testAccount(2, 8, RoleUser)
+
+	tests := []struct {
+		name       string
+		bearer     string
+		setupMock  func(store *MockStorage)
+		wantStatus int
+	}{
+		{
+			name:   "admin can create an account",
+			bearer: testJWT(t, admin),
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(admin)).Return(testActiveSession(admin), nil)
+				store.EXPECT().GetAccountByID(1).Return(admin, nil)
+				store.EXPECT().CreateAccount(gomock.Any()).Return(nil)
+				store.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "non-admin is forbidden",
+			bearer: testJWT(t, user),
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(user)).Return(testActiveSession(user), nil)
+				store.EXPECT().GetAccountByID(2).Return(user, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctrl := gomock.NewController(t)
+			store := NewMockStorage(ctrl)
+			tt.setupMock(store)
+
+			server := NewAPIServer(":0", store)
+
+			req := CreateAccountRequest{FirstName: "A", LastName: "B", Password: "password"}
+
+			rec := doRequest(server.routes(), http.MethodPost, "/account", req, tt.bearer)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandleRegister(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	store := NewMockStorage(ctrl)
+
+	store.EXPECT().CreateAccount(gomock.Any()).DoAndReturn(func(acc *Account) error {
+		assert.Equal(t, RoleUser, acc.Role)
+		return nil
+	})
+	store.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).Return(nil)
+
+	server := NewAPIServer(":0", store)
+
+	req := CreateAccountRequest{FirstName: "A", LastName: "B", Password: "password", Role: RoleAdmin}
+
+	rec := doRequest(server.routes(), http.MethodPost, "/register", req, "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandleDeleteAccount(t *testing.T) {
+
+	admin := testAccount(1, 7, RoleAdmin)
+	owner := testAccount(1, 42, RoleUser)
+
+	tests := []struct {
+		name       string
+		bearer     string
+		setupMock  func(store *MockStorage)
+		wantStatus int
+	}{
+		{
+			name:   "admin can delete an account",
+			bearer: testJWT(t, admin),
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(admin)).Return(testActiveSession(admin), nil)
+				store.EXPECT().GetAccountByID(1).Return(admin, nil)
+				store.EXPECT().DeleteAccount(1).Return(nil)
+				store.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "account owner cannot delete their own account",
+			bearer: testJWT(t, owner),
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(owner)).Return(testActiveSession(owner), nil)
+				store.EXPECT().GetAccountByID(1).Return(owner, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctrl := gomock.NewController(t)
+			store := NewMockStorage(ctrl)
+			tt.setupMock(store)
+
+			server := NewAPIServer(":0", store)
+
+			rec := doRequest(server.routes(), http.MethodDelete, "/account/1", nil, tt.bearer)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandleTransferAccount(t *testing.T) {
+
+	fromAcc := testAccount(1, 42, RoleUser)
+	intruder := testAccount(7, 7, RoleUser)
+
+	tests := []struct {
+		name       string
+		req        TransferRequest
+		bearer     string
+		setupMock  func(store *MockStorage)
+		wantStatus int
+	}{
+		{
+			name:   "authorized transfer succeeds",
+			req:    TransferRequest{FromAccount: 1, ToAccount: 2, Amount: 50},
+			bearer: testJWT(t, fromAcc),
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(fromAcc)).Return(testActiveSession(fromAcc), nil)
+				// authenticate resolves the caller, the handler loads the "from" account again to check it belongs to the caller.
+				store.EXPECT().GetAccountByID(1).Return(fromAcc, nil).Times(2)
+				store.EXPECT().TransferTx(gomock.Any(), TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 50}).
+					Return(TransferTxResult{}, nil)
+				store.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "transferring from someone else's account is forbidden",
+			req:    TransferRequest{FromAccount: 1, ToAccount: 2, Amount: 50},
+			bearer: testJWT(t, intruder),
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(intruder)).Return(testActiveSession(intruder), nil)
+				store.EXPECT().GetAccountByID(7).Return(intruder, nil)
+				store.EXPECT().GetAccountByID(1).Return(fromAcc, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "non-positive amount is rejected",
+			req:        TransferRequest{FromAccount: 1, ToAccount: 2, Amount: 0},
+			bearer:     testJWT(t, fromAcc),
+			setupMock:  func(store *MockStorage) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctrl := gomock.NewController(t)
+			store := NewMockStorage(ctrl)
+			tt.setupMock(store)
+
+			server := NewAPIServer(":0", store)
+
+			rec := doRequest(server.routes(), http.MethodPost, "/transfer", tt.req, tt.bearer)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandleRefresh(t *testing.T) {
+
+	acc := testAccount(1, 42, RoleUser)
+
+	tests := []struct {
+		name       string
+		req        RefreshRequest
+		setupMock  func(store *MockStorage)
+		wantStatus int
+	}{
+		{
+			name: "valid refresh token rotates the session",
+			req:  RefreshRequest{RefreshToken: "session-1.secret"},
+			setupMock: func(store *MockStorage) {
+				session := testActiveSession(acc)
+				session.RefreshTokenHash = hashRefreshSecret("secret")
+
+				store.EXPECT().GetSessionByID(gomock.Any(), "session-1").Return(session, nil)
+				store.EXPECT().GetAccountByID(1).Return(acc, nil)
+				store.EXPECT().RevokeSession(gomock.Any(), "session-1").Return(nil)
+				store.EXPECT().CreateSession(gomock.Any(), acc.ID, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(testActiveSession(acc), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "wrong secret is forbidden",
+			req:  RefreshRequest{RefreshToken: "session-1.wrong"},
+			setupMock: func(store *MockStorage) {
+				session := testActiveSession(acc)
+				session.RefreshTokenHash = hashRefreshSecret("secret")
+
+				store.EXPECT().GetSessionByID(gomock.Any(), "session-1").Return(session, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "revoked session is forbidden",
+			req:  RefreshRequest{RefreshToken: "session-1.secret"},
+			setupMock: func(store *MockStorage) {
+				revokedAt := time.Now().UTC()
+				session := testActiveSession(acc)
+				session.RevokedAt = &revokedAt
+
+				store.EXPECT().GetSessionByID(gomock.Any(), "session-1").Return(session, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctrl := gomock.NewController(t)
+			store := NewMockStorage(ctrl)
+			tt.setupMock(store)
+
+			server := NewAPIServer(":0", store)
+
+			rec := doRequest(server.routes(), http.MethodPost, "/auth/refresh", tt.req, "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandleLogout(t *testing.T) {
+
+	acc := testAccount(1, 42, RoleUser)
+
+	ctrl := gomock.NewController(t)
+	store := NewMockStorage(ctrl)
+
+	store.EXPECT().RevokeSession(gomock.Any(), sessionIDFor(acc)).Return(nil)
+
+	server := NewAPIServer(":0", store)
+
+	rec := doRequest(server.routes(), http.MethodPost, "/auth/logout", nil, testJWT(t, acc))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandleLogoutAll(t *testing.T) {
+
+	acc := testAccount(1, 42, RoleUser)
+
+	ctrl := gomock.NewController(t)
+	store := NewMockStorage(ctrl)
+
+	store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(acc)).Return(testActiveSession(acc), nil)
+	store.EXPECT().GetAccountByID(1).Return(acc, nil)
+	store.EXPECT().RevokeAllSessions(gomock.Any(), acc.ID).Return(nil)
+
+	server := NewAPIServer(":0", store)
+
+	rec := doRequest(server.routes(), http.MethodPost, "/auth/logout-all", nil, testJWT(t, acc))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandleListAuditEvents(t *testing.T) {
+
+	admin := testAccount(1, 7, RoleAdmin)
+	user := testAccount(2, 8, RoleUser)
+
+	tests := []struct {
+		name       string
+		bearer     string
+		query      string
+		setupMock  func(store *MockStorage)
+		wantStatus int
+	}{
+		{
+			name:   "admin can list audit events",
+			bearer: testJWT(t, admin),
+			query:  "account_id=2&action=transfer",
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(admin)).Return(testActiveSession(admin), nil)
+				store.EXPECT().GetAccountByID(1).Return(admin, nil)
+				store.EXPECT().ListAuditEvents(gomock.Any(), audit.Filter{AccountID: 2, Action: audit.ActionTransfer}).
+					Return([]audit.Event{}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "admin can fetch the next page",
+			bearer: testJWT(t, admin),
+			query:  "account_id=2&action=transfer&limit=50&offset=50",
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(admin)).Return(testActiveSession(admin), nil)
+				store.EXPECT().GetAccountByID(1).Return(admin, nil)
+				store.EXPECT().ListAuditEvents(gomock.Any(), audit.Filter{AccountID: 2, Action: audit.ActionTransfer, Limit: 50, Offset: 50}).
+					Return([]audit.Event{}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "non-admin is forbidden",
+			bearer: testJWT(t, user),
+			query:  "account_id=2&action=transfer",
+			setupMock: func(store *MockStorage) {
+				store.EXPECT().GetSessionByID(gomock.Any(), sessionIDFor(user)).Return(testActiveSession(user), nil)
+				store.EXPECT().GetAccountByID(2).Return(user, nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctrl := gomock.NewController(t)
+			store := NewMockStorage(ctrl)
+			tt.setupMock(store)
+
+			server := NewAPIServer(":0", store)
+
+			rec := doRequest(server.routes(), http.MethodGet, "/admin/audit?"+tt.query, nil, tt.bearer)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/shobhitexe/GoBank/pkg/audit"
+)
+
+// recordAudit best-effort persists a state-changing request to the audit
+// trail. A failure to write is logged but never surfaces to the client,
+// since auditing a request should never be the reason it fails.
+// actorAccountID is nil when the request never resolved to a known account,
+// e.g. a login attempt against a nonexistent account number.
+func recordAudit(ctx context.Context, store Storage, actorAccountID *int64, action string, targetAccountID *int64, r *http.Request, payload any, result string) {
+
+	payloadJSON, err := json.Marshal(payload)
+
+	if err != nil {
+		loggerFromContext(ctx).Error("failed to marshal audit payload", "action", action, "error", err)
+		return
+	}
+
+	event := audit.Event{
+		ActorAccountID:  actorAccountID,
+		Action:          action,
+		TargetAccountID: targetAccountID,
+		IP:              clientIP(r),
+		UserAgent:       r.UserAgent(),
+		RequestID:       requestIDFromContext(ctx),
+		Payload:         string(payloadJSON),
+		Result:          result,
+	}
+
+	if err := store.RecordAuditEvent(ctx, event); err != nil {
+		loggerFromContext(ctx).Error("failed to record audit event", "action", action, "error", err)
+	}
+}
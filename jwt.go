@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// AuthTokens is the pair handed back to a client after login, OAuth callback,
+// or a refresh: a short-lived access token plus the refresh token it can
+// later be traded in for a new one.
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// issueSession opens a new session row for account and mints the access/refresh
+// token pair backed by it. Login, the OAuth callback, and /auth/refresh all
+// funnel through here so every code path that hands out tokens also records
+// the session that can later revoke them.
+func issueSession(ctx context.Context, s Storage, account *Account, r *http.Request) (*AuthTokens, error) {
+
+	refreshSecret, refreshHash, err := newRefreshSecret()
+
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.CreateSession(ctx, account.ID, refreshHash, r.UserAgent(), clientIP(r), time.Now().UTC().Add(refreshTokenTTL))
+
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := createAccessToken(account, session.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: session.ID + "." + refreshSecret,
+	}, nil
+}
+
+// createAccessToken mints a short-lived JWT identifying account, scoped to
+// the session named by sessionID so a revoked session invalidates every
+// access token minted under it even before exp is reached.
+func createAccessToken(account *Account, sessionID string) (string, error) {
+
+	now := time.Now().UTC()
+
+	claims := jwt.MapClaims{
+		"sub": strconv.FormatInt(account.ID, 10),
+		"jti": sessionID,
+		"iat": jwt.NewNumericDate(now),
+		"nbf": jwt.NewNumericDate(now),
+		"exp": jwt.NewNumericDate(now.Add(accessTokenTTL)),
+	}
+
+	secret := os.Getenv("JWT_SECRET")
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	return token.SignedString([]byte(secret))
+}
+
+func validateJWT(tokenString string) (*jwt.Token, error) {
+
+	secret := os.Getenv("JWT_SECRET")
+
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+
+}
+
+// authenticate resolves the account and session a request's bearer token
+// belongs to. On any failure - a malformed/expired token or a revoked
+// session - it writes a 403 itself and returns ok=false so callers can just
+// return.
+func authenticate(w http.ResponseWriter, r *http.Request, s Storage) (*Account, bool) {
+
+	token, err := validateJWT(r.Header.Get("Authorization"))
+
+	if err != nil || !token.Valid {
+		PermissionDenied(w)
+
+		return nil, false
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+
+	session, err := s.GetSessionByID(r.Context(), fmt.Sprint(claims["jti"]))
+
+	if err != nil || session.RevokedAt != nil || session.ExpiresAt.Before(time.Now().UTC()) {
+		PermissionDenied(w)
+
+		return nil, false
+	}
+
+	accountID, err := strconv.Atoi(fmt.Sprint(claims["sub"]))
+
+	if err != nil {
+		PermissionDenied(w)
+
+		return nil, false
+	}
+
+	account, err := s.GetAccountByID(accountID)
+
+	if err != nil {
+		PermissionDenied(w)
+
+		return nil, false
+	}
+
+	return account, true
+}
+
+// requireRole builds a middleware that only lets callers whose JWT account
+// has the given role reach handlerFunc.
+func requireRole(role string) func(handlerFunc http.HandlerFunc, s Storage) http.HandlerFunc {
+
+	return func(handlerFunc http.HandlerFunc, s Storage) http.HandlerFunc {
+
+		return func(w http.ResponseWriter, r *http.Request) {
+
+			account, ok := authenticate(w, r, s)
+
+			if !ok {
+				return
+			}
+
+			if account.Role != role {
+				PermissionDenied(w)
+
+				return
+			}
+
+			handlerFunc(w, r.WithContext(contextWithAccount(r.Context(), account)))
+		}
+	}
+}
+
+// requireOwnerOrRole builds a middleware that lets the account named by the
+// {id} path param through, or any caller with the given role.
+func requireOwnerOrRole(role string) func(handlerFunc http.HandlerFunc, s Storage) http.HandlerFunc {
+
+	return func(handlerFunc http.HandlerFunc, s Storage) http.HandlerFunc {
+
+		return func(w http.ResponseWriter, r *http.Request) {
+
+			account, ok := authenticate(w, r, s)
+
+			if !ok {
+				return
+			}
+
+			ctx := contextWithAccount(r.Context(), account)
+
+			if account.Role == role {
+				handlerFunc(w, r.WithContext(ctx))
+
+				return
+			}
+
+			id, err := getID(r)
+
+			if err != nil {
+				PermissionDenied(w)
+
+				return
+			}
+
+			target, err := s.GetAccountByID(id)
+
+			if err != nil {
+				PermissionDenied(w)
+
+				return
+			}
+
+			if account.Number != target.Number {
+				PermissionDenied(w)
+
+				return
+			}
+
+			handlerFunc(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// newRefreshSecret returns a random refresh-token secret and the hash of it
+// that's safe to persist: the plaintext secret is only ever seen by the
+// client, never stored.
+func newRefreshSecret() (secret string, hash string, err error) {
+
+	raw := make([]byte, 32)
+
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	secret = base64.RawURLEncoding.EncodeToString(raw)
+
+	return secret, hashRefreshSecret(secret), nil
+}
+
+func hashRefreshSecret(secret string) string {
+
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// splitRefreshToken splits a "<sessionID>.<secret>" refresh token, mirroring
+// the nonce/signature split pkg/auth uses for OAuth state cookies.
+func splitRefreshToken(token string) (sessionID string, secret string, ok bool) {
+
+	for i := len(token) - 1; i >= 0; i-- {
+		if token[i] == '.' {
+			return token[:i], token[i+1:], true
+		}
+	}
+
+	return "", "", false
+}
+
+func clientIP(r *http.Request) string {
+
+	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
+		return ip
+	}
+
+	return r.RemoteAddr
+}
@@ -0,0 +1,47 @@
+// Package audit defines the shape of the service's audit trail: one Event
+// per state-changing request, persisted and queried through Storage.
+package audit
+
+import "time"
+
+// Actions recorded by the handlers in this service.
+const (
+	ActionCreateAccount = "create_account"
+	ActionDeleteAccount = "delete_account"
+	ActionTransfer      = "transfer"
+	ActionLoginSuccess  = "login_success"
+	ActionLoginFailure  = "login_failure"
+)
+
+// Results an Event can carry.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Event is one row of the audit trail: a state-changing request plus enough
+// context to answer "who did what, to whom, from where". ActorAccountID is
+// nil when the request never resolved to a known account, e.g. a login
+// attempt against a nonexistent account number.
+type Event struct {
+	ID              int64     `json:"id"`
+	Timestamp       time.Time `json:"ts"`
+	ActorAccountID  *int64    `json:"actorAccountId,omitempty"`
+	Action          string    `json:"action"`
+	TargetAccountID *int64    `json:"targetAccountId,omitempty"`
+	IP              string    `json:"ip"`
+	UserAgent       string    `json:"userAgent"`
+	RequestID       string    `json:"requestId"`
+	Payload         string    `json:"payloadJson"`
+	Result          string    `json:"result"`
+}
+
+// Filter narrows a List query. A zero AccountID, Action or Since means "don't
+// restrict on this field"; a zero Limit means the store's default page size.
+type Filter struct {
+	AccountID int64
+	Action    string
+	Since     time.Time
+	Limit     int
+	Offset    int
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+const githubUserInfoURL = "https://api.github.com/user"
+
+// GitHubProvider implements OAuthProvider against GitHub's OAuth2 endpoints.
+type GitHubProvider struct {
+	config *oauth2.Config
+}
+
+// NewGitHubProvider builds a GitHubProvider from a client ID/secret and the
+// callback URL this server is reachable at.
+func NewGitHubProvider(clientID, clientSecret, redirectURL string) *GitHubProvider {
+	return &GitHubProvider{
+		config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       []string{"read:user", "user:email"},
+			Endpoint:     github.Endpoint,
+		},
+	}
+}
+
+func (p *GitHubProvider) AuthURL(state string) string {
+	return p.config.AuthCodeURL(state)
+}
+
+func (p *GitHubProvider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	return p.config.Exchange(ctx, code)
+}
+
+type githubUserInfo struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (p *GitHubProvider) FetchProfile(ctx context.Context, token *oauth2.Token) (Profile, error) {
+
+	client := p.config.Client(ctx, token)
+
+	resp, err := client.Get(githubUserInfoURL)
+
+	if err != nil {
+		return Profile{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Profile{}, fmt.Errorf("github userinfo: unexpected status %d", resp.StatusCode)
+	}
+
+	var info githubUserInfo
+
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return Profile{}, err
+	}
+
+	firstName, lastName := info.Login, ""
+
+	if parts := strings.SplitN(strings.TrimSpace(info.Name), " ", 2); info.Name != "" {
+		firstName = parts[0]
+
+		if len(parts) == 2 {
+			lastName = parts[1]
+		}
+	}
+
+	return Profile{
+		Subject:   strconv.FormatInt(info.ID, 10),
+		Email:     info.Email,
+		FirstName: firstName,
+		LastName:  lastName,
+	}, nil
+}
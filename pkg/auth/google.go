@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const googleUserInfoURL = "https://www.googleapis.com/oauth2/v3/userinfo"
+
+// GoogleProvider implements OAuthProvider against Google's OAuth2 endpoints.
+type GoogleProvider struct {
+	config *oauth2.Config
+}
+
+// NewGoogleProvider builds a GoogleProvider from a client ID/secret and the
+// callback URL this server is reachable at.
+func NewGoogleProvider(clientID, clientSecret, redirectURL string) *GoogleProvider {
+	return &GoogleProvider{
+		config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       []string{"openid", "profile", "email"},
+			Endpoint:     google.Endpoint,
+		},
+	}
+}
+
+func (p *GoogleProvider) AuthURL(state string) string {
+	return p.config.AuthCodeURL(state)
+}
+
+func (p *GoogleProvider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	return p.config.Exchange(ctx, code)
+}
+
+type googleUserInfo struct {
+	Sub        string `json:"sub"`
+	Email      string `json:"email"`
+	GivenName  string `json:"given_name"`
+	FamilyName string `json:"family_name"`
+}
+
+func (p *GoogleProvider) FetchProfile(ctx context.Context, token *oauth2.Token) (Profile, error) {
+
+	client := p.config.Client(ctx, token)
+
+	resp, err := client.Get(googleUserInfoURL)
+
+	if err != nil {
+		return Profile{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Profile{}, fmt.Errorf("google userinfo: unexpected status %d", resp.StatusCode)
+	}
+
+	var info googleUserInfo
+
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return Profile{}, err
+	}
+
+	return Profile{
+		Subject:   info.Sub,
+		Email:     info.Email,
+		FirstName: info.GivenName,
+		LastName:  info.FamilyName,
+	}, nil
+}
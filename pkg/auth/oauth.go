@@ -0,0 +1,24 @@
+// Package auth provides OAuth2 sign-in alongside the API's password login.
+package auth
+
+import (
+	"context"
+
+	"golang.org/x/oauth2"
+)
+
+// Profile is the subset of an OAuth provider's user info we care about.
+type Profile struct {
+	Subject   string
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+// OAuthProvider exchanges an authorization code for a token and fetches the
+// signed-in user's profile. Google and GitHub are the concrete implementations.
+type OAuthProvider interface {
+	AuthURL(state string) string
+	Exchange(ctx context.Context, code string) (*oauth2.Token, error)
+	FetchProfile(ctx context.Context, token *oauth2.Token) (Profile, error)
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+)
+
+// NewState returns a random nonce paired with an HMAC signature over it, so the
+// callback handler can confirm the state it receives was the one we issued.
+func NewState(secret string) (nonce string, signed string, err error) {
+
+	raw := make([]byte, 16)
+
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	nonce = base64.RawURLEncoding.EncodeToString(raw)
+
+	return nonce, nonce + "." + sign(secret, nonce), nil
+}
+
+// ValidState reports whether cookieValue is the signed pair NewState produced
+// for nonce, and that nonce matches the state the provider echoed back.
+func ValidState(secret, cookieValue, nonce string) bool {
+
+	wantNonce, mac, ok := splitState(cookieValue)
+
+	if !ok || wantNonce != nonce {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(mac), []byte(sign(secret, wantNonce))) == 1
+}
+
+func splitState(signed string) (nonce string, mac string, ok bool) {
+
+	for i := len(signed) - 1; i >= 0; i-- {
+		if signed[i] == '.' {
+			return signed[:i], signed[i+1:], true
+		}
+	}
+
+	return "", "", false
+}
+
+func sign(secret, nonce string) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+
+	fmt.Fprint(mac, nonce)
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"os"
+	"time"
 
 	_ "github.com/lib/pq"
+
+	"github.com/google/uuid"
+
+	"github.com/shobhitexe/GoBank/pkg/audit"
+	"github.com/shobhitexe/GoBank/pkg/auth"
 )
 
 type Storage interface {
@@ -15,6 +23,30 @@ type Storage interface {
 	GetAccounts() ([]*Account, error)
 	GetAccountByID(int) (*Account, error)
 	GetAccountByNumber(int) (*Account, error)
+	TransferTx(ctx context.Context, params TransferTxParams) (TransferTxResult, error)
+	UpsertOAuthAccount(ctx context.Context, provider, subject string, profile auth.Profile) (*Account, error)
+	CreateSession(ctx context.Context, accountID int64, refreshTokenHash, userAgent, clientIP string, expiresAt time.Time) (*Session, error)
+	GetSessionByID(ctx context.Context, id string) (*Session, error)
+	RevokeSession(ctx context.Context, id string) error
+	RevokeAllSessions(ctx context.Context, accountID int64) error
+	RecordAuditEvent(ctx context.Context, event audit.Event) error
+	ListAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error)
+}
+
+// TransferTxParams holds the input to a TransferTx money movement.
+type TransferTxParams struct {
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+}
+
+// TransferTxResult is everything a successful TransferTx created or touched.
+type TransferTxResult struct {
+	Transfer    *Transfer `json:"transfer"`
+	FromEntry   *Entry    `json:"fromEntry"`
+	ToEntry     *Entry    `json:"toEntry"`
+	FromAccount *Account  `json:"fromAccount"`
+	ToAccount   *Account  `json:"toAccount"`
 }
 
 type PostgreStore struct {
@@ -36,7 +68,31 @@ func NewPostrgreStore() (*PostgreStore, error) {
 }
 
 func (s *PostgreStore) Init() error {
-	return s.createAccountTable()
+	if err := s.createAccountTable(); err != nil {
+		return err
+	}
+
+	if err := s.createTransferTable(); err != nil {
+		return err
+	}
+
+	if err := s.createEntryTable(); err != nil {
+		return err
+	}
+
+	if err := s.createOAuthIdentityTable(); err != nil {
+		return err
+	}
+
+	if err := s.createSessionTable(); err != nil {
+		return err
+	}
+
+	if err := s.createAuditEventTable(); err != nil {
+		return err
+	}
+
+	return s.seedAdminAccount()
 }
 
 func (s *PostgreStore) createAccountTable() error {
@@ -47,7 +103,8 @@ func (s *PostgreStore) createAccountTable() error {
 		number SERIAL NOT NULL,
 		encrypted_password VARCHAR(100) NOT NULL,
 		balance SERIAL NOT NULL,
-		created_at TIMESTAMP NOT NULL
+		created_at TIMESTAMP NOT NULL,
+		role TEXT NOT NULL DEFAULT 'user'
 	)`
 
 	_, err := s.db.Exec(query)
@@ -55,11 +112,44 @@ func (s *PostgreStore) createAccountTable() error {
 	return err
 }
 
+// seedAdminAccount creates the first privileged account from ADMIN_API_KEY when
+// the accounts table is still empty, mirroring how similar services bootstrap
+// their first admin user.
+func (s *PostgreStore) seedAdminAccount() error {
+
+	accounts, err := s.GetAccounts()
+
+	if err != nil {
+		return err
+	}
+
+	if len(accounts) > 0 {
+		return nil
+	}
+
+	apiKey := os.Getenv("ADMIN_API_KEY")
+
+	if apiKey == "" {
+		log.Println("warning: ADMIN_API_KEY not set, skipping admin account bootstrap")
+		return nil
+	}
+
+	admin, err := NewAccount("Admin", "Admin", apiKey)
+
+	if err != nil {
+		return err
+	}
+
+	admin.Role = RoleAdmin
+
+	return s.CreateAccount(admin)
+}
+
 func (s *PostgreStore) CreateAccount(acc *Account) error {
 
-	query := `INSERT INTO accounts (first_name, last_name, number, encrypted_password, balance, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	query := `INSERT INTO accounts (first_name, last_name, number, encrypted_password, balance, created_at, role) VALUES ($1, $2, $3, $4, $5, $6, $7)`
 
-	_, err := s.db.Query(query, acc.FirstName, acc.LastName, acc.Number, acc.EncryptedPassword, acc.Balance, acc.CreatedAt)
+	_, err := s.db.Query(query, acc.FirstName, acc.LastName, acc.Number, acc.EncryptedPassword, acc.Balance, acc.CreatedAt, acc.Role)
 
 	if err != nil {
 		return err
@@ -138,7 +228,428 @@ func scanIntoAccounts(rows *sql.Rows) (*Account, error) {
 
 	account := new(Account)
 
-	err := rows.Scan(&account.ID, &account.FirstName, &account.LastName, &account.Number, &account.EncryptedPassword, &account.Balance, &account.CreatedAt)
+	err := rows.Scan(&account.ID, &account.FirstName, &account.LastName, &account.Number, &account.EncryptedPassword, &account.Balance, &account.CreatedAt, &account.Role)
 
 	return account, err
 }
+
+func (s *PostgreStore) createTransferTable() error {
+	query := `CREATE TABLE IF NOT EXISTS transfers (
+		id SERIAL PRIMARY KEY,
+		from_account_id INTEGER NOT NULL REFERENCES accounts(id),
+		to_account_id INTEGER NOT NULL REFERENCES accounts(id),
+		amount BIGINT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT now()
+	)`
+
+	_, err := s.db.Exec(query)
+
+	return err
+}
+
+func (s *PostgreStore) createEntryTable() error {
+	query := `CREATE TABLE IF NOT EXISTS entries (
+		id SERIAL PRIMARY KEY,
+		account_id INTEGER NOT NULL REFERENCES accounts(id),
+		amount BIGINT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT now()
+	)`
+
+	_, err := s.db.Exec(query)
+
+	return err
+}
+
+// execTx runs fn inside a serializable transaction, committing on success and
+// rolling back on error so future multi-statement flows can reuse the pattern.
+func (s *PostgreStore) execTx(ctx context.Context, fn func(*sql.Tx) error) error {
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx error: %v, rollback error: %v", err, rbErr)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgreStore) TransferTx(ctx context.Context, params TransferTxParams) (TransferTxResult, error) {
+
+	var result TransferTxResult
+
+	if params.Amount <= 0 {
+		return result, fmt.Errorf("transfer amount must be positive")
+	}
+
+	if params.FromAccountID == params.ToAccountID {
+		return result, fmt.Errorf("cannot transfer to the same account")
+	}
+
+	err := s.execTx(ctx, func(tx *sql.Tx) error {
+
+		var err error
+
+		result.Transfer, err = createTransfer(tx, params)
+
+		if err != nil {
+			return err
+		}
+
+		result.FromEntry, err = createEntry(tx, params.FromAccountID, -params.Amount)
+
+		if err != nil {
+			return err
+		}
+
+		result.ToEntry, err = createEntry(tx, params.ToAccountID, params.Amount)
+
+		if err != nil {
+			return err
+		}
+
+		// Lock the two accounts in a deterministic order (smallest id first) to avoid deadlocks,
+		// then reuse whichever of the two locked rows is the "from" account instead of
+		// re-querying it a third time.
+		firstID, secondID := params.FromAccountID, params.ToAccountID
+
+		if firstID > secondID {
+			firstID, secondID = secondID, firstID
+		}
+
+		firstAccount, err := lockAccountForUpdate(tx, firstID)
+
+		if err != nil {
+			return err
+		}
+
+		secondAccount, err := lockAccountForUpdate(tx, secondID)
+
+		if err != nil {
+			return err
+		}
+
+		fromAccount := firstAccount
+
+		if fromAccount.ID != params.FromAccountID {
+			fromAccount = secondAccount
+		}
+
+		if fromAccount.Balance < params.Amount {
+			return fmt.Errorf("account %d has insufficient balance", params.FromAccountID)
+		}
+
+		result.FromAccount, err = addAccountBalance(tx, params.FromAccountID, -params.Amount)
+
+		if err != nil {
+			return err
+		}
+
+		result.ToAccount, err = addAccountBalance(tx, params.ToAccountID, params.Amount)
+
+		return err
+	})
+
+	return result, err
+}
+
+func createTransfer(tx *sql.Tx, params TransferTxParams) (*Transfer, error) {
+
+	transfer := &Transfer{}
+
+	query := `INSERT INTO transfers (from_account_id, to_account_id, amount) VALUES ($1, $2, $3) RETURNING id, from_account_id, to_account_id, amount, created_at`
+
+	row := tx.QueryRow(query, params.FromAccountID, params.ToAccountID, params.Amount)
+
+	err := row.Scan(&transfer.ID, &transfer.FromAccountID, &transfer.ToAccountID, &transfer.Amount, &transfer.CreatedAt)
+
+	return transfer, err
+}
+
+func createEntry(tx *sql.Tx, accountID int64, amount int64) (*Entry, error) {
+
+	entry := &Entry{}
+
+	query := `INSERT INTO entries (account_id, amount) VALUES ($1, $2) RETURNING id, account_id, amount, created_at`
+
+	row := tx.QueryRow(query, accountID, amount)
+
+	err := row.Scan(&entry.ID, &entry.AccountID, &entry.Amount, &entry.CreatedAt)
+
+	return entry, err
+}
+
+func lockAccountForUpdate(tx *sql.Tx, accountID int64) (*Account, error) {
+
+	account := &Account{}
+
+	query := `SELECT id, first_name, last_name, number, encrypted_password, balance, created_at, role FROM accounts WHERE id = $1 FOR UPDATE`
+
+	row := tx.QueryRow(query, accountID)
+
+	err := row.Scan(&account.ID, &account.FirstName, &account.LastName, &account.Number, &account.EncryptedPassword, &account.Balance, &account.CreatedAt, &account.Role)
+
+	return account, err
+}
+
+func addAccountBalance(tx *sql.Tx, accountID int64, amount int64) (*Account, error) {
+
+	account := &Account{}
+
+	query := `UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING id, first_name, last_name, number, encrypted_password, balance, created_at, role`
+
+	row := tx.QueryRow(query, amount, accountID)
+
+	err := row.Scan(&account.ID, &account.FirstName, &account.LastName, &account.Number, &account.EncryptedPassword, &account.Balance, &account.CreatedAt, &account.Role)
+
+	return account, err
+}
+
+func (s *PostgreStore) createOAuthIdentityTable() error {
+	query := `CREATE TABLE IF NOT EXISTS oauth_identities (
+		id SERIAL PRIMARY KEY,
+		account_id INTEGER NOT NULL REFERENCES accounts(id),
+		provider TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT now(),
+		UNIQUE (provider, subject)
+	)`
+
+	_, err := s.db.Exec(query)
+
+	return err
+}
+
+// UpsertOAuthAccount links the given provider/subject to an account, creating
+// the account (with no password, since OAuth users never set one) the first
+// time that identity signs in.
+func (s *PostgreStore) UpsertOAuthAccount(ctx context.Context, provider, subject string, profile auth.Profile) (*Account, error) {
+
+	var account *Account
+
+	err := s.execTx(ctx, func(tx *sql.Tx) error {
+
+		existing, err := getAccountByOAuthIdentity(tx, provider, subject)
+
+		if err == nil {
+			account = existing
+			return nil
+		}
+
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		account, err = createOAuthAccount(tx, profile)
+
+		if err != nil {
+			return err
+		}
+
+		return linkOAuthIdentity(tx, account.ID, provider, subject)
+	})
+
+	return account, err
+}
+
+func getAccountByOAuthIdentity(tx *sql.Tx, provider, subject string) (*Account, error) {
+
+	account := &Account{}
+
+	query := `SELECT a.id, a.first_name, a.last_name, a.number, a.encrypted_password, a.balance, a.created_at, a.role
+		FROM accounts a
+		JOIN oauth_identities o ON o.account_id = a.id
+		WHERE o.provider = $1 AND o.subject = $2`
+
+	row := tx.QueryRow(query, provider, subject)
+
+	err := row.Scan(&account.ID, &account.FirstName, &account.LastName, &account.Number, &account.EncryptedPassword, &account.Balance, &account.CreatedAt, &account.Role)
+
+	return account, err
+}
+
+func createOAuthAccount(tx *sql.Tx, profile auth.Profile) (*Account, error) {
+
+	query := `INSERT INTO accounts (first_name, last_name, number, encrypted_password, balance, created_at, role)
+		VALUES ($1, $2, nextval('accounts_number_seq'), '', 0, now(), $3)
+		RETURNING id, first_name, last_name, number, encrypted_password, balance, created_at, role`
+
+	account := &Account{}
+
+	row := tx.QueryRow(query, profile.FirstName, profile.LastName, RoleUser)
+
+	err := row.Scan(&account.ID, &account.FirstName, &account.LastName, &account.Number, &account.EncryptedPassword, &account.Balance, &account.CreatedAt, &account.Role)
+
+	return account, err
+}
+
+func linkOAuthIdentity(tx *sql.Tx, accountID int64, provider, subject string) error {
+
+	_, err := tx.Exec(`INSERT INTO oauth_identities (account_id, provider, subject) VALUES ($1, $2, $3)`, accountID, provider, subject)
+
+	return err
+}
+
+func (s *PostgreStore) createSessionTable() error {
+	query := `CREATE TABLE IF NOT EXISTS sessions (
+		id UUID PRIMARY KEY,
+		account_id INTEGER NOT NULL REFERENCES accounts(id),
+		refresh_token_hash TEXT NOT NULL,
+		user_agent TEXT NOT NULL,
+		client_ip TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL DEFAULT now()
+	)`
+
+	_, err := s.db.Exec(query)
+
+	return err
+}
+
+func (s *PostgreStore) CreateSession(ctx context.Context, accountID int64, refreshTokenHash, userAgent, clientIP string, expiresAt time.Time) (*Session, error) {
+
+	session := &Session{ID: uuid.NewString()}
+
+	query := `INSERT INTO sessions (id, account_id, refresh_token_hash, user_agent, client_ip, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, account_id, refresh_token_hash, user_agent, client_ip, expires_at, revoked_at, created_at`
+
+	row := s.db.QueryRowContext(ctx, query, session.ID, accountID, refreshTokenHash, userAgent, clientIP, expiresAt)
+
+	err := row.Scan(&session.ID, &session.AccountID, &session.RefreshTokenHash, &session.UserAgent, &session.ClientIP, &session.ExpiresAt, &session.RevokedAt, &session.CreatedAt)
+
+	return session, err
+}
+
+func (s *PostgreStore) GetSessionByID(ctx context.Context, id string) (*Session, error) {
+
+	session := &Session{}
+
+	query := `SELECT id, account_id, refresh_token_hash, user_agent, client_ip, expires_at, revoked_at, created_at FROM sessions WHERE id = $1`
+
+	row := s.db.QueryRowContext(ctx, query, id)
+
+	err := row.Scan(&session.ID, &session.AccountID, &session.RefreshTokenHash, &session.UserAgent, &session.ClientIP, &session.ExpiresAt, &session.RevokedAt, &session.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *PostgreStore) RevokeSession(ctx context.Context, id string) error {
+
+	_, err := s.db.ExecContext(ctx, `UPDATE sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+
+	return err
+}
+
+func (s *PostgreStore) RevokeAllSessions(ctx context.Context, accountID int64) error {
+
+	_, err := s.db.ExecContext(ctx, `UPDATE sessions SET revoked_at = now() WHERE account_id = $1 AND revoked_at IS NULL`, accountID)
+
+	return err
+}
+
+func (s *PostgreStore) createAuditEventTable() error {
+	query := `CREATE TABLE IF NOT EXISTS audit_events (
+		id SERIAL PRIMARY KEY,
+		ts TIMESTAMP NOT NULL DEFAULT now(),
+		actor_account_id INTEGER REFERENCES accounts(id),
+		action TEXT NOT NULL,
+		target_account_id INTEGER REFERENCES accounts(id),
+		ip TEXT NOT NULL,
+		user_agent TEXT NOT NULL,
+		request_id TEXT NOT NULL,
+		payload_json TEXT NOT NULL,
+		result TEXT NOT NULL
+	)`
+
+	_, err := s.db.Exec(query)
+
+	return err
+}
+
+// RecordAuditEvent persists a single audit trail row. Callers treat this as
+// best-effort: a write failure is logged, not propagated to the client whose
+// request triggered it.
+func (s *PostgreStore) RecordAuditEvent(ctx context.Context, event audit.Event) error {
+
+	query := `INSERT INTO audit_events (actor_account_id, action, target_account_id, ip, user_agent, request_id, payload_json, result)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := s.db.ExecContext(ctx, query, event.ActorAccountID, event.Action, event.TargetAccountID, event.IP, event.UserAgent, event.RequestID, event.Payload, event.Result)
+
+	return err
+}
+
+const defaultAuditPageSize = 50
+
+// ListAuditEvents pages through the audit trail ordered by most recent
+// first, narrowed by whichever of filter's fields are non-zero.
+func (s *PostgreStore) ListAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error) {
+
+	query := `SELECT id, ts, actor_account_id, action, target_account_id, ip, user_agent, request_id, payload_json, result
+		FROM audit_events WHERE 1=1`
+
+	args := []any{}
+
+	if filter.AccountID != 0 {
+		args = append(args, filter.AccountID)
+		query += fmt.Sprintf(" AND (actor_account_id = $%d OR target_account_id = $%d)", len(args), len(args))
+	}
+
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		query += fmt.Sprintf(" AND action = $%d", len(args))
+	}
+
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		query += fmt.Sprintf(" AND ts >= $%d", len(args))
+	}
+
+	limit := filter.Limit
+
+	if limit <= 0 {
+		limit = defaultAuditPageSize
+	}
+
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY ts DESC LIMIT $%d", len(args))
+
+	args = append(args, filter.Offset)
+	query += fmt.Sprintf(" OFFSET $%d", len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	events := []audit.Event{}
+
+	for rows.Next() {
+
+		var event audit.Event
+
+		if err := rows.Scan(&event.ID, &event.Timestamp, &event.ActorAccountID, &event.Action, &event.TargetAccountID, &event.IP, &event.UserAgent, &event.RequestID, &event.Payload, &event.Result); err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type LoginRequest struct {
+	Number   int64  `json:"number"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	Number       int64  `json:"number"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type CreateAccountRequest struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Password  string `json:"password"`
+	Role      string `json:"role,omitempty"`
+}
+
+type TransferRequest struct {
+	FromAccount int64 `json:"fromAccount"`
+	ToAccount   int64 `json:"toAccount"`
+	Amount      int64 `json:"amount"`
+}
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+type Account struct {
+	ID                int64     `json:"id"`
+	FirstName         string    `json:"firstName"`
+	LastName          string    `json:"lastName"`
+	Number            int64     `json:"number"`
+	EncryptedPassword string    `json:"-"`
+	Balance           int64     `json:"balance"`
+	Role              string    `json:"role"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+func (a *Account) ValidatePassword(pw string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(a.EncryptedPassword), []byte(pw)) == nil
+}
+
+type Transfer struct {
+	ID            int64     `json:"id"`
+	FromAccountID int64     `json:"fromAccountId"`
+	ToAccountID   int64     `json:"toAccountId"`
+	Amount        int64     `json:"amount"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type Entry struct {
+	ID        int64     `json:"id"`
+	AccountID int64     `json:"accountId"`
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Session backs a refresh token: RefreshTokenHash never leaves the store, and
+// RevokedAt being non-nil means every access token minted under this session
+// (its jti) must be rejected.
+type Session struct {
+	ID               string     `json:"id"`
+	AccountID        int64      `json:"accountId"`
+	RefreshTokenHash string     `json:"-"`
+	UserAgent        string     `json:"userAgent"`
+	ClientIP         string     `json:"clientIp"`
+	ExpiresAt        time.Time  `json:"expiresAt"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+}
+
+func NewAccount(firstName, lastName, password string) (*Account, error) {
+
+	encpw, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Account{
+		FirstName:         firstName,
+		LastName:          lastName,
+		Number:            int64(rand.Intn(1000000)),
+		EncryptedPassword: string(encpw),
+		Role:              RoleUser,
+		CreatedAt:         time.Now().UTC(),
+	}, nil
+}